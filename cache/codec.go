@@ -0,0 +1,284 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Codec compresses and decompresses values before they're stored in, and after they're read back
+// from, a cache backend. Implementations must be safe for concurrent use.
+type Codec interface {
+	// Encode compresses value, returning the payload to hand to the cache backend.
+	Encode(value []byte) ([]byte, error)
+
+	// Decode decompresses a payload previously returned by Encode.
+	Decode(payload []byte) ([]byte, error)
+}
+
+// Every payload written by a Codec is prefixed with codecMagicHeader followed by one of these
+// codec IDs, so Decode can auto-detect which codec wrote it, regardless of the codec currently
+// configured. This keeps entries written before codecs existed (with no header at all) readable:
+// they're treated as raw by legacyCodec.
+const (
+	codecMagicRaw    byte = 0x00
+	codecMagicSnappy byte = 0x01
+	codecMagicS2     byte = 0x02
+	codecMagicZstd   byte = 0x03
+)
+
+// codecMagicHeader precedes the codec ID byte in every payload written by a Codec. A legacy entry
+// (written before this feature existed) has no header, so a single magic byte isn't enough to tell
+// the two apart: about 1 in 256 arbitrary legacy payloads would start with a byte that happens to
+// match a codec ID. A multi-byte, deliberately unlikely header cuts a false-positive match down to
+// roughly 1 in 2^32.
+var codecMagicHeader = [4]byte{0xd5, 0x6b, 0xc0, 0xde}
+
+// validateCodecName reports whether name is a codec recognised by newCodec.
+func validateCodecName(name string) error {
+	switch name {
+	case "", "none", "snappy", "s2", "zstd":
+		return nil
+	default:
+		return fmt.Errorf("unsupported codec %q: must be one of none, snappy, s2, zstd", name)
+	}
+}
+
+// newCodec builds the Codec named by name ("none", "snappy", "s2" or "zstd"), instrumented with
+// compression ratio and per-op codec time metrics. Values smaller than minCompressSize are always
+// stored raw, regardless of name.
+func newCodec(name string, minCompressSize int, reg prometheus.Registerer) (Codec, error) {
+	var codec Codec
+	switch name {
+	case "", "none":
+		codec = rawCodec{}
+	case "snappy":
+		codec = snappyCodec{}
+	case "s2":
+		codec = s2Codec{}
+	case "zstd":
+		zc, err := newZstdCodec()
+		if err != nil {
+			return nil, err
+		}
+		codec = zc
+	default:
+		return nil, fmt.Errorf("unsupported codec %q: must be one of none, snappy, s2, zstd", name)
+	}
+
+	return &instrumentedCodec{
+		codec:   codec,
+		minSize: minCompressSize,
+		metrics: newCodecMetrics(reg),
+	}, nil
+}
+
+// rawCodec stores values unmodified, prefixed with codecMagicRaw.
+type rawCodec struct{}
+
+func (rawCodec) Encode(value []byte) ([]byte, error) { return prefix(codecMagicRaw, value), nil }
+func (rawCodec) Decode(payload []byte) ([]byte, error) {
+	return unprefix(codecMagicRaw, payload)
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Encode(value []byte) ([]byte, error) {
+	return prefix(codecMagicSnappy, snappy.Encode(nil, value)), nil
+}
+
+func (snappyCodec) Decode(payload []byte) ([]byte, error) {
+	body, err := unprefix(codecMagicSnappy, payload)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Decode(nil, body)
+}
+
+type s2Codec struct{}
+
+func (s2Codec) Encode(value []byte) ([]byte, error) {
+	return prefix(codecMagicS2, s2.Encode(nil, value)), nil
+}
+
+func (s2Codec) Decode(payload []byte) ([]byte, error) {
+	body, err := unprefix(codecMagicS2, payload)
+	if err != nil {
+		return nil, err
+	}
+	return s2.Decode(nil, body)
+}
+
+// zstdCodec wraps a pair of reusable zstd encoder/decoder, since constructing them per call is
+// expensive.
+type zstdCodec struct {
+	enc *zstd.Encoder
+	dec *zstd.Decoder
+}
+
+func newZstdCodec() (*zstdCodec, error) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdCodec{enc: enc, dec: dec}, nil
+}
+
+func (z *zstdCodec) Encode(value []byte) ([]byte, error) {
+	return prefix(codecMagicZstd, z.enc.EncodeAll(value, nil)), nil
+}
+
+func (z *zstdCodec) Decode(payload []byte) ([]byte, error) {
+	body, err := unprefix(codecMagicZstd, payload)
+	if err != nil {
+		return nil, err
+	}
+	return z.dec.DecodeAll(body, nil)
+}
+
+func prefix(magic byte, body []byte) []byte {
+	out := make([]byte, len(codecMagicHeader)+1+len(body))
+	n := copy(out, codecMagicHeader[:])
+	out[n] = magic
+	copy(out[n+1:], body)
+	return out
+}
+
+// splitMagic reports whether payload starts with codecMagicHeader, returning the codec ID and
+// remaining body if so.
+func splitMagic(payload []byte) (id byte, body []byte, ok bool) {
+	if len(payload) < len(codecMagicHeader)+1 || !bytes.Equal(payload[:len(codecMagicHeader)], codecMagicHeader[:]) {
+		return 0, nil, false
+	}
+	return payload[len(codecMagicHeader)], payload[len(codecMagicHeader)+1:], true
+}
+
+func unprefix(want byte, payload []byte) ([]byte, error) {
+	id, body, ok := splitMagic(payload)
+	if !ok {
+		return nil, errors.New("missing codec magic header")
+	}
+	if id != want {
+		return nil, errors.Errorf("unexpected codec magic byte %#x", id)
+	}
+	return body, nil
+}
+
+// detectCodec inspects payload's magic header and returns the Codec able to decode it, regardless
+// of what the caller currently has configured. Entries written before codecs existed have no magic
+// header at all and are treated as legacy raw values.
+func detectCodec(payload []byte) (Codec, error) {
+	id, _, ok := splitMagic(payload)
+	if !ok {
+		// No recognised magic header: assume it's a legacy entry written before codecs existed.
+		return legacyCodec{}, nil
+	}
+	switch id {
+	case codecMagicRaw:
+		return rawCodec{}, nil
+	case codecMagicSnappy:
+		return snappyCodec{}, nil
+	case codecMagicS2:
+		return s2Codec{}, nil
+	case codecMagicZstd:
+		return newZstdCodec()
+	default:
+		// Unrecognised codec ID behind a recognised header: most likely a legacy entry that
+		// happened to collide with the header by chance. Treat it as raw rather than erroring.
+		return legacyCodec{}, nil
+	}
+}
+
+// legacyCodec reads back values written before codecs were introduced, which have no magic
+// byte prefix at all.
+type legacyCodec struct{}
+
+func (legacyCodec) Encode(value []byte) ([]byte, error)   { return value, nil }
+func (legacyCodec) Decode(payload []byte) ([]byte, error) { return payload, nil }
+
+type codecMetrics struct {
+	ratio      prometheus.Histogram
+	encodeTime *prometheus.HistogramVec
+	decodeTime *prometheus.HistogramVec
+}
+
+func newCodecMetrics(reg prometheus.Registerer) *codecMetrics {
+	return &codecMetrics{
+		ratio: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cache_codec_compression_ratio",
+			Help:    "Ratio of compressed to uncompressed payload size for values that were compressed (encoded size / raw size).",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 0.75, 0.9, 1},
+		}),
+		encodeTime: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_codec_encode_duration_seconds",
+			Help:    "Time spent encoding a value before writing it to the cache backend, by codec.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"codec"}),
+		decodeTime: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_codec_decode_duration_seconds",
+			Help:    "Time spent decoding a value read back from the cache backend, by codec.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"codec"}),
+	}
+}
+
+// instrumentedCodec wraps a Codec, skipping compression for values smaller than minSize and
+// recording compression ratio / codec time metrics.
+type instrumentedCodec struct {
+	codec   Codec
+	minSize int
+	metrics *codecMetrics
+}
+
+func (c *instrumentedCodec) name() string {
+	switch c.codec.(type) {
+	case snappyCodec:
+		return "snappy"
+	case s2Codec:
+		return "s2"
+	case *zstdCodec:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+func (c *instrumentedCodec) Encode(value []byte) ([]byte, error) {
+	if len(value) < c.minSize {
+		return rawCodec{}.Encode(value)
+	}
+
+	start := time.Now()
+	encoded, err := c.codec.Encode(value)
+	c.metrics.encodeTime.WithLabelValues(c.name()).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	if len(value) > 0 {
+		c.metrics.ratio.Observe(float64(len(encoded)) / float64(len(value)))
+	}
+	return encoded, nil
+}
+
+func (c *instrumentedCodec) Decode(payload []byte) ([]byte, error) {
+	codec, err := detectCodec(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	decoded, err := codec.Decode(payload)
+	c.metrics.decodeTime.WithLabelValues(c.name()).Observe(time.Since(start).Seconds())
+	return decoded, err
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	payloads := map[string][]byte{
+		"empty":  {},
+		"small":  []byte("hello world"),
+		"large":  randomBytes(t, 4*1024*1024),
+		"binary": {0x00, 0x01, 0x02, 0x03, 0xff, 0xfe},
+	}
+
+	for _, name := range []string{"none", "snappy", "s2", "zstd"} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := newCodec(name, 0, prometheus.NewRegistry())
+			require.NoError(t, err)
+
+			for payloadName, payload := range payloads {
+				t.Run(payloadName, func(t *testing.T) {
+					encoded, err := codec.Encode(payload)
+					require.NoError(t, err)
+
+					decoded, err := codec.Decode(encoded)
+					require.NoError(t, err)
+					require.True(t, bytes.Equal(payload, decoded))
+				})
+			}
+		})
+	}
+}
+
+// TestCodecs_DecodeAcrossVersions verifies that a value written by one configured codec can still
+// be decoded after the configured codec changes, since Decode auto-detects the codec from the
+// payload's magic header rather than trusting the caller's current configuration.
+func TestCodecs_DecodeAcrossVersions(t *testing.T) {
+	payload := randomBytes(t, 1024*1024)
+
+	snappy, err := newCodec("snappy", 0, prometheus.NewRegistry())
+	require.NoError(t, err)
+	zstd, err := newCodec("zstd", 0, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	encoded, err := snappy.Encode(payload)
+	require.NoError(t, err)
+
+	decoded, err := zstd.Decode(encoded)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(payload, decoded))
+}
+
+// TestDetectCodec_LegacyEntriesWithoutHeader verifies that a pre-existing entry written before
+// codecs were introduced (no magic header at all) is treated as a legacy raw value, even when its
+// first bytes happen to collide with a single codec ID byte.
+func TestDetectCodec_LegacyEntriesWithoutHeader(t *testing.T) {
+	for _, first := range []byte{codecMagicRaw, codecMagicSnappy, codecMagicS2, codecMagicZstd} {
+		legacy := append([]byte{first}, []byte("rest of a legacy value")...)
+
+		codec, err := detectCodec(legacy)
+		require.NoError(t, err)
+		require.IsType(t, legacyCodec{}, codec)
+
+		decoded, err := codec.Decode(legacy)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(legacy, decoded))
+	}
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	_, err := rand.New(rand.NewSource(42)).Read(b)
+	require.NoError(t, err)
+	return b
+}
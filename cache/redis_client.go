@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	dstls "github.com/grafana/dskit/crypto/tls"
 	"github.com/grafana/dskit/flagext"
@@ -27,6 +29,10 @@ var (
 	_ RemoteCacheClient = (*redisClient)(nil)
 )
 
+// opSetMulti is the metrics label used for SetMulti, mirroring the existing opGetMulti label used
+// for GetMulti.
+const opSetMulti = "setmulti"
+
 // RedisClientConfig is the config accepted by RedisClient.
 type RedisClientConfig struct {
 	// Endpoint specifies the endpoint of Redis server.
@@ -49,6 +55,32 @@ type RedisClientConfig struct {
 	// MasterName is Redis Sentinel master name. An empty string for Redis Server or Redis Cluster.
 	MasterName string `yaml:"master_name" category:"advanced"`
 
+	// SentinelAddrs is the list of Redis Sentinel addresses to use for master/replica discovery
+	// and automatic failover. An empty list disables Sentinel support, even if MasterName is set.
+	SentinelAddrs flagext.StringSliceCSV `yaml:"sentinel_addrs" category:"advanced"`
+
+	// SentinelUsername is the username used to authenticate with the Sentinels themselves, as
+	// opposed to Username which authenticates with the Redis master/replicas.
+	SentinelUsername string `yaml:"sentinel_username" category:"advanced"`
+
+	// SentinelPassword is the password used to authenticate with the Sentinels themselves, as
+	// opposed to Password which authenticates with the Redis master/replicas.
+	SentinelPassword flagext.Secret `yaml:"sentinel_password" category:"advanced"`
+
+	// RouteByLatency, when ReadOnly is enabled, routes read commands to the replica that reports
+	// the lowest latency rather than a random one.
+	RouteByLatency bool `yaml:"route_by_latency" category:"advanced"`
+
+	// RouteRandomly, when ReadOnly is enabled, routes read commands to a random replica instead of
+	// always preferring the master.
+	RouteRandomly bool `yaml:"route_randomly" category:"advanced"`
+
+	// ReadOnly dispatches read commands to replicas, discovered and monitored through Sentinel,
+	// instead of always hitting the master. Writes always target the master. When SentinelAddrs
+	// is empty, it's passed on to the client built from Endpoint, where it only affects a Redis
+	// Cluster client.
+	ReadOnly bool `yaml:"read_only" category:"advanced"`
+
 	// DialTimeout specifies the client dial timeout.
 	DialTimeout time.Duration `yaml:"dial_timeout" category:"advanced"`
 
@@ -92,11 +124,41 @@ type RedisClientConfig struct {
 	// GetMultiBatchSize specifies the maximum size per batch for mget.
 	GetMultiBatchSize int `yaml:"get_multi_batch_size" category:"advanced"`
 
+	// MaxSetMultiConcurrency specifies the maximum number of concurrent SetMulti() operations.
+	// If set to 0, concurrency is unlimited.
+	MaxSetMultiConcurrency int `yaml:"max_set_multi_concurrency" category:"advanced"`
+
+	// SetMultiBatchSize specifies the maximum size per batch for mset.
+	SetMultiBatchSize int `yaml:"set_multi_batch_size" category:"advanced"`
+
 	// TLSEnabled enable TLS for Redis connection.
 	TLSEnabled bool `yaml:"tls_enabled" category:"advanced"`
 
 	// TLS to use to connect to the Redis server.
 	TLS dstls.ClientConfig `yaml:",inline"`
+
+	// ClientSideCacheDisabled disables the server-assisted client-side cache used by NewRueidisClient.
+	// When disabled, every Get/GetMulti issues a network round-trip to Redis.
+	ClientSideCacheDisabled bool `yaml:"client_side_cache_disabled" category:"advanced"`
+
+	// CacheSize is the maximum amount of memory, in bytes, used by the client-side cache of each
+	// connection to hold values served by Redis' RESP3 tracking invalidation; the total across
+	// connections can be larger. Only used by NewRueidisClient.
+	CacheSize int `yaml:"cache_size" category:"advanced"`
+
+	// CacheTTL is the maximum amount of time a value can be served from the client-side cache.
+	// It's automatically capped to the item's remaining server-side TTL (via PTTL). Only used by
+	// NewRueidisClient.
+	CacheTTL time.Duration `yaml:"cache_ttl" category:"advanced"`
+
+	// Codec selects the codec used to compress values before they're written to Redis: none,
+	// snappy, s2 or zstd. Decode auto-detects the codec used to write an entry regardless of this
+	// setting, so changing it is always backward compatible with entries already in Redis.
+	Codec string `yaml:"codec" category:"advanced"`
+
+	// MinCompressSize is the minimum value size, in bytes, below which values are stored raw even
+	// if Codec is not "none".
+	MinCompressSize int `yaml:"min_compress_size" category:"advanced"`
 }
 
 // RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet
@@ -106,6 +168,12 @@ func (c *RedisClientConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagS
 	f.Var(&c.Password, prefix+".password", "Password to use when connecting to Redis.")
 	f.IntVar(&c.DB, prefix+".db", 0, "Database index.")
 	f.StringVar(&c.MasterName, prefix+".master-name", "", "Redis Sentinel master name. An empty string for Redis Server or Redis Cluster.")
+	f.Var(&c.SentinelAddrs, prefix+".sentinel-addrs", "A comma-separated list of Redis Sentinel addresses used for master/replica discovery and automatic failover. If set, takes precedence over endpoint for connecting to Redis.")
+	f.StringVar(&c.SentinelUsername, prefix+".sentinel-username", "", "Username to use when connecting to the Redis Sentinels, as opposed to the Redis master/replicas.")
+	f.Var(&c.SentinelPassword, prefix+".sentinel-password", "Password to use when connecting to the Redis Sentinels, as opposed to the Redis master/replicas.")
+	f.BoolVar(&c.RouteByLatency, prefix+".route-by-latency", false, "Route read commands to the replica with the lowest latency. Only used when read-only is enabled.")
+	f.BoolVar(&c.RouteRandomly, prefix+".route-randomly", false, "Route read commands to a random replica. Only used when read-only is enabled.")
+	f.BoolVar(&c.ReadOnly, prefix+".read-only", false, "Dispatch read commands to replicas instead of the master. Writes always target the master. Applies to Redis Sentinel (sentinel-addrs) and Redis Cluster deployments.")
 	f.DurationVar(&c.DialTimeout, prefix+".dial-timeout", time.Second*5, "Client dial timeout.")
 	f.DurationVar(&c.ReadTimeout, prefix+".read-timeout", time.Second*3, "Client read timeout.")
 	f.DurationVar(&c.WriteTimeout, prefix+".write-timeout", time.Second*3, "Client write timeout.")
@@ -118,21 +186,32 @@ func (c *RedisClientConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagS
 	f.IntVar(&c.MaxAsyncBufferSize, prefix+".max-async-buffer-size", 25000, "The maximum number of enqueued asynchronous operations allowed.")
 	f.IntVar(&c.MaxGetMultiConcurrency, prefix+".max-get-multi-concurrency", 100, "The maximum number of concurrent connections running get operations. If set to 0, concurrency is unlimited.")
 	f.IntVar(&c.GetMultiBatchSize, prefix+".max-get-multi-batch-size", 100, "The maximum size per batch for mget operations.")
+	f.IntVar(&c.MaxSetMultiConcurrency, prefix+".max-set-multi-concurrency", 100, "The maximum number of concurrent connections running set operations. If set to 0, concurrency is unlimited.")
+	f.IntVar(&c.SetMultiBatchSize, prefix+".max-set-multi-batch-size", 100, "The maximum size per batch for mset operations.")
 	f.IntVar(&c.MaxItemSize, prefix+".max-item-size", 16*1024*1024, "The maximum size of an item stored in Redis. Bigger items are not stored. If set to 0, no maximum size is enforced.")
 
 	f.BoolVar(&c.TLSEnabled, prefix+".tls-enabled", false, "Enable connecting to Redis with TLS.")
 	c.TLS.RegisterFlagsWithPrefix(prefix, f)
+
+	f.BoolVar(&c.ClientSideCacheDisabled, prefix+".client-side-cache-disabled", false, "Disable the client-side cache used by the Rueidis client. Only used when the Rueidis client is enabled.")
+	f.IntVar(&c.CacheSize, prefix+".cache-size", 128*1024*1024, "The maximum amount of memory, in bytes, used by the client-side cache of each connection. Only used when the Rueidis client is enabled.")
+	f.DurationVar(&c.CacheTTL, prefix+".cache-ttl", time.Minute, "The maximum amount of time a value can be served from the client-side cache before it is revalidated against Redis. Only used when the Rueidis client is enabled.")
+
+	f.StringVar(&c.Codec, prefix+".codec", "none", "The codec used to compress values before storing them in Redis: none, snappy, s2 or zstd.")
+	f.IntVar(&c.MinCompressSize, prefix+".min-compress-size", 1024, "Values smaller than this size, in bytes, are stored uncompressed even if a codec is configured.")
 }
 
 func (c *RedisClientConfig) Validate() error {
-	if c.Endpoint.String() == "" {
+	// A Sentinel-based deployment discovers the master/replica endpoints itself, so Endpoint is
+	// only required when SentinelAddrs isn't set.
+	if c.Endpoint.String() == "" && len(c.SentinelAddrs) == 0 {
 		return errRedisConfigNoEndpoint
 	}
 	// Set async only available when MaxAsyncConcurrency > 0.
 	if c.MaxAsyncConcurrency <= 0 {
 		return errRedisMaxAsyncConcurrencyNotPositive
 	}
-	return nil
+	return validateCodecName(c.Codec)
 }
 
 type redisClient struct {
@@ -144,28 +223,59 @@ type redisClient struct {
 	// getMultiGate used to enforce the max number of concurrent GetMulti() operations.
 	getMultiGate gate.Gate
 
+	// setMultiGate used to enforce the max number of concurrent SetMulti() operations.
+	setMultiGate gate.Gate
+
+	// isRealCluster reports whether UniversalClient is a *redis.ClusterClient backed by genuine
+	// Redis Cluster addresses, as opposed to a Sentinel-managed FailoverClusterClient (which is
+	// also a *redis.ClusterClient under the hood, but maps the whole slot range onto a single
+	// master/replica set rather than sharding across distinct nodes). GetMulti/SetMulti only take
+	// the per-slot fanout path when this is true; otherwise CRC16 slot-grouping would turn every
+	// batched call into one MGET/MSET per key for no benefit.
+	isRealCluster bool
+
+	// shardDuration and shardErrors are only populated when isRealCluster is true, and track the
+	// per-slot MGET/MSET pipelines dispatched by GetMulti/SetMulti so operators can spot hot or
+	// failing shards.
+	shardDuration *prometheus.HistogramVec
+	shardErrors   *prometheus.CounterVec
+
+	codec Codec
+
+	// sentinelFailovers counts +switch-master events observed on the Sentinels, when
+	// config.SentinelAddrs is set.
+	sentinelFailovers   prometheus.Counter
+	cancelSentinelWatch context.CancelFunc
+
 	logger log.Logger
 }
 
 // NewRedisClient makes a new RedisClient.
 func NewRedisClient(logger log.Logger, name string, config RedisClientConfig, reg prometheus.Registerer) (RemoteCacheClient, error) {
 	opts := &redis.UniversalOptions{
-		Addrs:        strings.Split(config.Endpoint.String(), ","),
-		Username:     config.Username,
-		Password:     config.Password.String(),
-		DB:           config.DB,
-		MasterName:   config.MasterName,
-		DialTimeout:  config.DialTimeout,
-		ReadTimeout:  config.ReadTimeout,
-		WriteTimeout: config.WriteTimeout,
-		PoolSize:     config.ConnectionPoolSize,
-		MinIdleConns: config.MinIdleConnections,
-		MaxConnAge:   config.MaxConnectionAge,
-		IdleTimeout:  config.IdleTimeout,
+		Addrs:            strings.Split(config.Endpoint.String(), ","),
+		Username:         config.Username,
+		Password:         config.Password.String(),
+		DB:               config.DB,
+		MasterName:       config.MasterName,
+		SentinelUsername: config.SentinelUsername,
+		SentinelPassword: config.SentinelPassword.String(),
+		RouteByLatency:   config.RouteByLatency,
+		RouteRandomly:    config.RouteRandomly,
+		ReadOnly:         config.ReadOnly,
+		DialTimeout:      config.DialTimeout,
+		ReadTimeout:      config.ReadTimeout,
+		WriteTimeout:     config.WriteTimeout,
+		PoolSize:         config.ConnectionPoolSize,
+		MinIdleConns:     config.MinIdleConnections,
+		MaxConnAge:       config.MaxConnectionAge,
+		IdleTimeout:      config.IdleTimeout,
 	}
 
+	var tlsClientConfig *tls.Config
 	if config.TLSEnabled {
-		tlsClientConfig, err := config.TLS.GetTLSConfig()
+		var err error
+		tlsClientConfig, err = config.TLS.GetTLSConfig()
 		if err != nil {
 			return nil, err
 		}
@@ -174,14 +284,38 @@ func NewRedisClient(logger log.Logger, name string, config RedisClientConfig, re
 
 	reg = prometheus.WrapRegistererWith(prometheus.Labels{"name": name}, reg)
 
+	codec, err := newCodec(config.Codec, config.MinCompressSize, prometheus.WrapRegistererWithPrefix("redis_", reg))
+	if err != nil {
+		return nil, err
+	}
+
 	metrics := newClientMetrics(
 		prometheus.WrapRegistererWithPrefix("redis_", reg),
 	)
 	c := &redisClient{
 		baseClient:      newBaseClient(logger, uint64(config.MaxItemSize), config.MaxAsyncBufferSize, config.MaxAsyncConcurrency, metrics),
-		UniversalClient: redis.NewUniversalClient(opts),
-		config:          config,
-		logger:          log.With(logger, "name", name),
+		UniversalClient: buildRedisUniversalClient(config, opts),
+		// A FailoverClusterClient (built when SentinelAddrs + ReadOnly are set) is also a
+		// *redis.ClusterClient, but it isn't a real Redis Cluster: there's no CROSSSLOT
+		// constraint to respect, so only treat a cluster built from real Endpoint addresses as
+		// one.
+		isRealCluster: len(config.SentinelAddrs) == 0,
+		config:        config,
+		logger:        log.With(logger, "name", name),
+		codec:         codec,
+		shardDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "redis_cluster_shard_duration_seconds",
+			Help:    "Duration of per-slot MGET/MSET pipelines dispatched against a Redis Cluster, by target shard.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation", "shard"}),
+		shardErrors: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "redis_cluster_shard_errors_total",
+			Help: "Total number of per-slot MGET/MSET pipelines that failed against a Redis Cluster, by target shard.",
+		}, []string{"operation", "shard"}),
+		sentinelFailovers: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "redis_sentinel_failovers_total",
+			Help: "Total number of Sentinel +switch-master events observed. Only populated when sentinel-addrs is set.",
+		}),
 	}
 	if config.MaxGetMultiConcurrency > 0 {
 		c.getMultiGate = gate.New(
@@ -189,13 +323,117 @@ func NewRedisClient(logger log.Logger, name string, config RedisClientConfig, re
 			config.MaxGetMultiConcurrency,
 		)
 	}
+	if config.MaxSetMultiConcurrency > 0 {
+		c.setMultiGate = gate.New(
+			prometheus.WrapRegistererWithPrefix("redis_setmulti_", reg),
+			config.MaxSetMultiConcurrency,
+		)
+	}
+	if len(config.SentinelAddrs) > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.cancelSentinelWatch = cancel
+		go c.watchSentinelFailovers(ctx, tlsClientConfig)
+	}
 	return c, nil
 }
 
+// buildRedisUniversalClient returns a FailoverClient or FailoverClusterClient when
+// config.SentinelAddrs is set, since Sentinel servers are addressed separately from the Redis
+// endpoints they monitor; otherwise it falls back to the existing single/cluster client behaviour.
+func buildRedisUniversalClient(config RedisClientConfig, opts *redis.UniversalOptions) redis.UniversalClient {
+	if len(config.SentinelAddrs) == 0 {
+		return redis.NewUniversalClient(opts)
+	}
+
+	if config.ReadOnly {
+		// A FailoverClusterClient lets read commands be routed to replicas via
+		// RouteByLatency/RouteRandomly. It happens to be a *redis.ClusterClient under the hood,
+		// but it isn't a real Redis Cluster (the whole slot range maps to a single master/replica
+		// set), so redisClient.isRealCluster is false for it and GetMulti/SetMulti issue a single
+		// MGET/MSET rather than per-slot fanout.
+		return redis.NewFailoverClusterClient(&redis.FailoverOptions{
+			MasterName:       config.MasterName,
+			SentinelAddrs:    []string(config.SentinelAddrs),
+			SentinelUsername: config.SentinelUsername,
+			SentinelPassword: config.SentinelPassword.String(),
+			Username:         config.Username,
+			Password:         config.Password.String(),
+			DB:               config.DB,
+			RouteByLatency:   config.RouteByLatency,
+			RouteRandomly:    config.RouteRandomly,
+			DialTimeout:      config.DialTimeout,
+			ReadTimeout:      config.ReadTimeout,
+			WriteTimeout:     config.WriteTimeout,
+			PoolSize:         config.ConnectionPoolSize,
+			MinIdleConns:     config.MinIdleConnections,
+			MaxConnAge:       config.MaxConnectionAge,
+			IdleTimeout:      config.IdleTimeout,
+			TLSConfig:        opts.TLSConfig,
+		})
+	}
+
+	return redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:       config.MasterName,
+		SentinelAddrs:    []string(config.SentinelAddrs),
+		SentinelUsername: config.SentinelUsername,
+		SentinelPassword: config.SentinelPassword.String(),
+		Username:         config.Username,
+		Password:         config.Password.String(),
+		DB:               config.DB,
+		DialTimeout:      config.DialTimeout,
+		ReadTimeout:      config.ReadTimeout,
+		WriteTimeout:     config.WriteTimeout,
+		PoolSize:         config.ConnectionPoolSize,
+		MinIdleConns:     config.MinIdleConnections,
+		MaxConnAge:       config.MaxConnectionAge,
+		IdleTimeout:      config.IdleTimeout,
+		TLSConfig:        opts.TLSConfig,
+	})
+}
+
+// watchSentinelFailovers subscribes to the +switch-master pub/sub channel on the first reachable
+// Sentinel and logs/counts master-switch events until ctx is cancelled, so operators can alert on
+// failovers.
+func (c *redisClient) watchSentinelFailovers(ctx context.Context, tlsConfig *tls.Config) {
+	addrs := []string(c.config.SentinelAddrs)
+	if len(addrs) == 0 {
+		return
+	}
+	addr := addrs[0]
+
+	sentinel := redis.NewSentinelClient(&redis.Options{
+		Addr:      addr,
+		Username:  c.config.SentinelUsername,
+		Password:  c.config.SentinelPassword.String(),
+		TLSConfig: tlsConfig,
+	})
+	defer sentinel.Close()
+
+	sub := sentinel.Subscribe(ctx, "+switch-master")
+	defer sub.Close()
+
+	for {
+		msg, err := sub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			level.Warn(c.logger).Log("msg", "lost connection to redis sentinel, giving up on failover watch", "addr", addr, "err", err)
+			return
+		}
+		c.sentinelFailovers.Inc()
+		level.Info(c.logger).Log("msg", "redis sentinel master switch detected", "payload", msg.Payload)
+	}
+}
+
 // SetAsync implement RemoteCacheClient.
 func (c *redisClient) SetAsync(ctx context.Context, key string, value []byte, ttl time.Duration) error {
 	return c.setAsync(ctx, key, value, ttl, func(ctx context.Context, key string, buf []byte, ttl time.Duration) error {
-		_, err := c.Set(ctx, key, value, ttl).Result()
+		encoded, err := c.codec.Encode(buf)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode value")
+		}
+		_, err = c.Set(ctx, key, encoded, ttl).Result()
 		return err
 	})
 }
@@ -205,6 +443,10 @@ func (c *redisClient) GetMulti(ctx context.Context, keys []string, _ ...Option)
 	if len(keys) == 0 {
 		return nil
 	}
+	if cluster, ok := c.UniversalClient.(*redis.ClusterClient); ok && c.isRealCluster {
+		return c.getMultiCluster(ctx, cluster, keys)
+	}
+
 	var mu sync.Mutex
 	results := make(map[string][]byte, len(keys))
 
@@ -226,8 +468,13 @@ func (c *redisClient) GetMulti(ctx context.Context, keys []string, _ ...Option)
 			key := currentKeys[i]
 			switch val := resp[i].(type) {
 			case string:
-				cacheHitBytes += len(val)
-				results[key] = stringToBytes(val)
+				decoded, err := c.codec.Decode(stringToBytes(val))
+				if err != nil {
+					level.Warn(c.logger).Log("msg", "failed to decode cached value", "key", key, "err", err)
+					continue
+				}
+				cacheHitBytes += len(decoded)
+				results[key] = decoded
 			case nil: // miss
 			default:
 				level.Warn(c.logger).Log("msg",
@@ -245,6 +492,187 @@ func (c *redisClient) GetMulti(ctx context.Context, keys []string, _ ...Option)
 	return results
 }
 
+// getMultiCluster groups keys by their CRC16 slot and dispatches one MGET pipeline per slot
+// concurrently (bounded by getMultiGate), since a Redis Cluster MGET can only span keys that
+// belong to the same slot.
+func (c *redisClient) getMultiCluster(ctx context.Context, cluster *redis.ClusterClient, keys []string) map[string][]byte {
+	bySlot := groupKeysBySlot(keys)
+	shardNames := clusterShardNames(ctx, cluster)
+
+	var mu sync.Mutex
+	results := make(map[string][]byte, len(keys))
+
+	// Every per-slot failure is recorded via shardErrors below and simply omits that slot's keys
+	// from the result, so doWithBatch itself never returns an error here.
+	_ = doWithBatch(ctx, len(bySlot), 1, c.getMultiGate, func(startIndex, _ int) error {
+		slot := bySlot[startIndex]
+		shard := shardNames[slot.slot]
+		if shard == "" {
+			shard = fmt.Sprintf("slot-%d", slot.slot)
+		}
+
+		start := time.Now()
+		c.metrics.operations.WithLabelValues(opGetMulti).Inc()
+
+		resp, err := cluster.MGet(ctx, slot.keys...).Result()
+		c.shardDuration.WithLabelValues(opGetMulti, shard).Observe(time.Since(start).Seconds())
+		if err != nil {
+			c.shardErrors.WithLabelValues(opGetMulti, shard).Inc()
+			level.Warn(c.logger).Log("msg", "failed to mget items from redis shard", "err", err, "shard", shard, "items", len(slot.keys))
+			return nil
+		}
+
+		var cacheHitBytes int
+		mu.Lock()
+		defer mu.Unlock()
+		for i, val := range resp {
+			key := slot.keys[i]
+			switch v := val.(type) {
+			case string:
+				decoded, err := c.codec.Decode(stringToBytes(v))
+				if err != nil {
+					level.Warn(c.logger).Log("msg", "failed to decode cached value", "key", key, "err", err)
+					continue
+				}
+				cacheHitBytes += len(decoded)
+				results[key] = decoded
+			case nil: // miss
+			default:
+				level.Warn(c.logger).Log("msg", fmt.Sprintf("unexpected redis mget result type:%T %v", val, val))
+			}
+		}
+		c.metrics.dataSize.WithLabelValues(opGetMulti).Observe(float64(cacheHitBytes))
+		return nil
+	})
+	return results
+}
+
+// SetMulti sets multiple key/value pairs with the same TTL. Against a Redis Cluster, keys are
+// grouped by their CRC16 slot and dispatched concurrently as per-slot MSET pipelines, bounded by
+// MaxSetMultiConcurrency; the TTL is then applied with a best-effort EXPIRE per key, since MSET
+// itself does not support one. Against a non-cluster deployment, it falls back to one MSET per
+// batch of at most SetMultiBatchSize keys.
+func (c *redisClient) SetMulti(ctx context.Context, data map[string][]byte, ttl time.Duration) error {
+	if len(data) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+
+	if cluster, ok := c.UniversalClient.(*redis.ClusterClient); ok && c.isRealCluster {
+		return c.setMultiCluster(ctx, cluster, data, keys, ttl)
+	}
+
+	return doWithBatch(ctx, len(keys), c.config.SetMultiBatchSize, c.setMultiGate, func(startIndex, endIndex int) error {
+		start := time.Now()
+		c.metrics.operations.WithLabelValues(opSetMulti).Inc()
+
+		currentKeys := keys[startIndex:endIndex]
+		pairs := make([]interface{}, 0, len(currentKeys)*2)
+		for _, key := range currentKeys {
+			encoded, err := c.codec.Encode(data[key])
+			if err != nil {
+				return errors.Wrapf(err, "failed to encode value for key %q", key)
+			}
+			pairs = append(pairs, key, encoded)
+		}
+		if err := c.MSet(ctx, pairs...).Err(); err != nil {
+			return errors.Wrap(err, "failed to mset items in redis")
+		}
+		if ttl > 0 {
+			for _, key := range currentKeys {
+				if err := c.Expire(ctx, key, ttl).Err(); err != nil {
+					return errors.Wrapf(err, "failed to set ttl for key %q", key)
+				}
+			}
+		}
+		c.metrics.duration.WithLabelValues(opSetMulti).Observe(time.Since(start).Seconds())
+		return nil
+	})
+}
+
+func (c *redisClient) setMultiCluster(ctx context.Context, cluster *redis.ClusterClient, data map[string][]byte, keys []string, ttl time.Duration) error {
+	bySlot := groupKeysBySlot(keys)
+	shardNames := clusterShardNames(ctx, cluster)
+
+	return doWithBatch(ctx, len(bySlot), 1, c.setMultiGate, func(startIndex, _ int) error {
+		slot := bySlot[startIndex]
+		shard := shardNames[slot.slot]
+		if shard == "" {
+			shard = fmt.Sprintf("slot-%d", slot.slot)
+		}
+
+		start := time.Now()
+		c.metrics.operations.WithLabelValues(opSetMulti).Inc()
+
+		pairs := make([]interface{}, 0, len(slot.keys)*2)
+		for _, key := range slot.keys {
+			encoded, err := c.codec.Encode(data[key])
+			if err != nil {
+				return errors.Wrapf(err, "failed to encode value for key %q", key)
+			}
+			pairs = append(pairs, key, encoded)
+		}
+		pipe := cluster.Pipeline()
+		pipe.MSet(ctx, pairs...)
+		if ttl > 0 {
+			for _, key := range slot.keys {
+				pipe.Expire(ctx, key, ttl)
+			}
+		}
+		_, err := pipe.Exec(ctx)
+		c.shardDuration.WithLabelValues(opSetMulti, shard).Observe(time.Since(start).Seconds())
+		if err != nil {
+			c.shardErrors.WithLabelValues(opSetMulti, shard).Inc()
+			return errors.Wrapf(err, "failed to mset items in redis shard %q", shard)
+		}
+		return nil
+	})
+}
+
+// keysBySlot is a group of keys that all hash to the same Redis Cluster slot.
+type keysBySlot struct {
+	slot int
+	keys []string
+}
+
+// groupKeysBySlot groups keys by their CRC16 slot, as required to batch them into a single
+// MGET/MSET against a Redis Cluster.
+func groupKeysBySlot(keys []string) []keysBySlot {
+	bySlot := make(map[int][]string, len(keys))
+	for _, key := range keys {
+		slot := redis.Slot(key)
+		bySlot[slot] = append(bySlot[slot], key)
+	}
+	groups := make([]keysBySlot, 0, len(bySlot))
+	for slot, slotKeys := range bySlot {
+		groups = append(groups, keysBySlot{slot: slot, keys: slotKeys})
+	}
+	return groups
+}
+
+// clusterShardNames maps every slot owned by the cluster to the address of the node currently
+// responsible for it, for use as a metric label. It's resolved once per GetMulti/SetMulti call
+// rather than per slot group, since CLUSTER SLOTS is itself a round-trip to Redis.
+func clusterShardNames(ctx context.Context, cluster *redis.ClusterClient) map[int]string {
+	names := make(map[int]string)
+	state, err := cluster.ClusterSlots(ctx).Result()
+	if err != nil {
+		return names
+	}
+	for _, s := range state {
+		if len(s.Nodes) == 0 {
+			continue
+		}
+		for slot := s.Start; slot <= s.End; slot++ {
+			names[slot] = s.Nodes[0].Addr
+		}
+	}
+	return names
+}
+
 // Delete implement RemoteCacheClient.
 func (c *redisClient) Delete(ctx context.Context, key string) error {
 	return c.delete(ctx, key, func(ctx context.Context, key string) error {
@@ -257,6 +685,10 @@ func (c *redisClient) Stop() {
 	// Stop running async operations.
 	c.asyncQueue.stop()
 
+	if c.cancelSentinelWatch != nil {
+		c.cancelSentinelWatch()
+	}
+
 	if err := c.Close(); err != nil {
 		level.Error(c.logger).Log("msg", "redis close err")
 	}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRedisUniversalClient(t *testing.T) {
+	t.Run("no sentinel addrs returns a plain universal client", func(t *testing.T) {
+		config := RedisClientConfig{Endpoint: []string{"localhost:6379"}}
+		client := buildRedisUniversalClient(config, &redis.UniversalOptions{Addrs: []string{"localhost:6379"}})
+		defer client.Close()
+
+		require.IsType(t, &redis.Client{}, client)
+	})
+
+	t.Run("sentinel addrs without read-only returns a failover client", func(t *testing.T) {
+		config := RedisClientConfig{SentinelAddrs: []string{"localhost:26379"}, MasterName: "mymaster"}
+		client := buildRedisUniversalClient(config, &redis.UniversalOptions{})
+		defer client.Close()
+
+		require.IsType(t, &redis.Client{}, client)
+	})
+
+	t.Run("sentinel addrs with read-only returns a replica-aware failover cluster client", func(t *testing.T) {
+		config := RedisClientConfig{SentinelAddrs: []string{"localhost:26379"}, MasterName: "mymaster", ReadOnly: true}
+		client := buildRedisUniversalClient(config, &redis.UniversalOptions{})
+		defer client.Close()
+
+		// A FailoverClusterClient is a *redis.ClusterClient under the hood, even though it isn't
+		// backed by a real sharded Redis Cluster.
+		require.IsType(t, &redis.ClusterClient{}, client)
+	})
+}
+
+// TestRedisClient_IsRealCluster checks, for each kind of *redis.ClusterClient returned by
+// buildRedisUniversalClient, the condition NewRedisClient uses to set isRealCluster
+// (len(config.SentinelAddrs) == 0), so that a Sentinel-backed client (config.SentinelAddrs +
+// config.ReadOnly) is not mistaken for a genuine sharded Redis Cluster. GetMulti/SetMulti only take
+// the per-slot fanout path when isRealCluster is true.
+func TestRedisClient_IsRealCluster(t *testing.T) {
+	t.Run("sentinel-backed cluster client is not a real cluster", func(t *testing.T) {
+		config := RedisClientConfig{SentinelAddrs: []string{"localhost:26379"}, MasterName: "mymaster", ReadOnly: true}
+		client := buildRedisUniversalClient(config, &redis.UniversalOptions{})
+		defer client.Close()
+
+		_, ok := client.(*redis.ClusterClient)
+		require.True(t, ok, "expected a *redis.ClusterClient")
+		require.False(t, len(config.SentinelAddrs) == 0, "isRealCluster must be false for a Sentinel-backed client")
+	})
+
+	t.Run("plain cluster client is a real cluster", func(t *testing.T) {
+		config := RedisClientConfig{Endpoint: []string{"localhost:7000", "localhost:7001"}}
+		client := buildRedisUniversalClient(config, &redis.UniversalOptions{Addrs: []string(config.Endpoint)})
+		defer client.Close()
+
+		_, ok := client.(*redis.ClusterClient)
+		require.True(t, ok, "expected a *redis.ClusterClient")
+		require.True(t, len(config.SentinelAddrs) == 0, "isRealCluster must be true for a genuine cluster client")
+	})
+}
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupKeysBySlot(t *testing.T) {
+	keys := []string{"foo", "bar", "baz", "qux", "foo"}
+
+	groups := groupKeysBySlot(keys)
+
+	bySlot := make(map[int][]string, len(groups))
+	var total int
+	for _, g := range groups {
+		bySlot[g.slot] = g.keys
+		total += len(g.keys)
+	}
+	// "foo" appears twice in the input; both occurrences hash to the same slot and must be
+	// preserved, since SetMulti relies on every input key being represented in exactly one group.
+	require.Equal(t, len(keys), total)
+
+	for _, key := range keys {
+		slot := redis.Slot(key)
+		require.Contains(t, bySlot[slot], key)
+	}
+}
@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/rueidis"
+
+	"github.com/grafana/dskit/gate"
+)
+
+var _ RemoteCacheClient = (*rueidisClient)(nil)
+
+// rueidisClientSideCacheMetrics tracks the effectiveness of the server-assisted client-side cache
+// used by rueidisClient.
+type rueidisClientSideCacheMetrics struct {
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	evictions prometheus.Counter
+}
+
+func newRueidisClientSideCacheMetrics(reg prometheus.Registerer) *rueidisClientSideCacheMetrics {
+	return &rueidisClientSideCacheMetrics{
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "redis_client_side_cache_hits_total",
+			Help: "Total number of Redis requests served from the client-side cache without a network round-trip.",
+		}),
+		misses: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "redis_client_side_cache_misses_total",
+			Help: "Total number of Redis requests that could not be served from the client-side cache.",
+		}),
+		evictions: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "redis_client_side_cache_evictions_total",
+			Help: "Total number of client-side cache entries evicted because Redis pushed an invalidation notification.",
+		}),
+	}
+}
+
+// rueidisClient is a RemoteCacheClient backed by the Rueidis driver. Unlike redisClient, it opts
+// into Redis 6+ RESP3 client-side caching (CLIENT TRACKING) so that hot keys can be served out of
+// an in-process LRU without a round-trip to Redis.
+type rueidisClient struct {
+	*baseClient
+	client rueidis.Client
+
+	config RedisClientConfig
+
+	getMultiGate gate.Gate
+
+	codec Codec
+
+	csc    *rueidisClientSideCacheMetrics
+	logger log.Logger
+}
+
+// NewRueidisClient makes a new RemoteCacheClient backed by the Rueidis driver with server-assisted
+// client-side caching enabled, unless config.ClientSideCacheDisabled is set.
+func NewRueidisClient(logger log.Logger, name string, config RedisClientConfig, reg prometheus.Registerer) (RemoteCacheClient, error) {
+	if config.Endpoint.String() == "" {
+		return nil, errRedisConfigNoEndpoint
+	}
+	if config.MaxAsyncConcurrency <= 0 {
+		return nil, errRedisMaxAsyncConcurrencyNotPositive
+	}
+
+	reg = prometheus.WrapRegistererWith(prometheus.Labels{"name": name}, reg)
+	logger = log.With(logger, "name", name)
+
+	csc := newRueidisClientSideCacheMetrics(reg)
+
+	opt := rueidis.ClientOption{
+		InitAddress:       strings.Split(config.Endpoint.String(), ","),
+		Username:          config.Username,
+		Password:          config.Password.String(),
+		SelectDB:          config.DB,
+		ConnWriteTimeout:  config.WriteTimeout,
+		Dialer:            net.Dialer{Timeout: config.DialTimeout},
+		DisableCache:      config.ClientSideCacheDisabled,
+		CacheSizeEachConn: config.CacheSize,
+		OnInvalidations: func(messages []rueidis.RedisMessage) {
+			// messages is nil when Redis asks us to flush the whole cache (e.g. after a
+			// CLIENT TRACKING redirection is re-established), rather than a specific key.
+			if messages == nil {
+				level.Debug(logger).Log("msg", "client-side cache flushed by redis")
+				return
+			}
+			csc.evictions.Add(float64(len(messages)))
+		},
+	}
+
+	if config.TLSEnabled {
+		tlsClientConfig, err := config.TLS.GetTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		opt.TLSConfig = tlsClientConfig
+	}
+
+	client, err := rueidis.NewClient(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := newCodec(config.Codec, config.MinCompressSize, prometheus.WrapRegistererWithPrefix("redis_", reg))
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := newClientMetrics(
+		prometheus.WrapRegistererWithPrefix("redis_", reg),
+	)
+	c := &rueidisClient{
+		baseClient: newBaseClient(logger, uint64(config.MaxItemSize), config.MaxAsyncBufferSize, config.MaxAsyncConcurrency, metrics),
+		client:     client,
+		config:     config,
+		codec:      codec,
+		csc:        csc,
+		logger:     logger,
+	}
+	if config.MaxGetMultiConcurrency > 0 {
+		c.getMultiGate = gate.New(
+			prometheus.WrapRegistererWithPrefix("redis_getmulti_", reg),
+			config.MaxGetMultiConcurrency,
+		)
+	}
+	return c, nil
+}
+
+// cacheTTL returns the max local TTL to request for a cached entry, which Rueidis caps against the
+// item's remaining server-side TTL (via PTTL) on our behalf.
+func (c *rueidisClient) cacheTTL() time.Duration {
+	if c.config.CacheTTL <= 0 {
+		return time.Minute
+	}
+	return c.config.CacheTTL
+}
+
+// SetAsync implement RemoteCacheClient.
+func (c *rueidisClient) SetAsync(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.setAsync(ctx, key, value, ttl, func(ctx context.Context, key string, buf []byte, ttl time.Duration) error {
+		encoded, err := c.codec.Encode(buf)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode value")
+		}
+		cmd := c.client.B().Set().Key(key).Value(rueidis.BinaryString(encoded)).Px(ttl).Build()
+		return c.client.Do(ctx, cmd).Error()
+	})
+}
+
+// GetMulti implement RemoteCacheClient.
+func (c *rueidisClient) GetMulti(ctx context.Context, keys []string, _ ...Option) map[string][]byte {
+	if len(keys) == 0 {
+		return nil
+	}
+	results := make(map[string][]byte, len(keys))
+
+	err := doWithBatch(ctx, len(keys), c.config.GetMultiBatchSize, c.getMultiGate, func(startIndex, endIndex int) error {
+		start := time.Now()
+		c.metrics.operations.WithLabelValues(opGetMulti).Inc()
+
+		currentKeys := keys[startIndex:endIndex]
+		cmds := make([]rueidis.CacheableTTL, len(currentKeys))
+		for i, key := range currentKeys {
+			cmds[i] = rueidis.CT(c.client.B().Get().Key(key).Cache(), c.cacheTTL())
+		}
+
+		var cacheHitBytes int
+		resps := c.client.DoMultiCache(ctx, cmds...)
+		for i, resp := range resps {
+			if resp.IsCacheHit() {
+				c.csc.hits.Inc()
+			} else {
+				c.csc.misses.Inc()
+			}
+
+			val, err := resp.ToString()
+			if err != nil {
+				if rueidis.IsRedisNil(err) {
+					continue // miss
+				}
+				level.Warn(c.logger).Log("msg", "failed to get item from redis", "err", err)
+				continue
+			}
+			decoded, err := c.codec.Decode([]byte(val))
+			if err != nil {
+				level.Warn(c.logger).Log("msg", "failed to decode cached value", "key", currentKeys[i], "err", err)
+				continue
+			}
+			cacheHitBytes += len(decoded)
+			results[currentKeys[i]] = decoded
+		}
+		c.metrics.dataSize.WithLabelValues(opGetMulti).Observe(float64(cacheHitBytes))
+		c.metrics.duration.WithLabelValues(opGetMulti).Observe(time.Since(start).Seconds())
+		return nil
+	})
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to get items from redis", "err", err, "items", len(keys))
+		return nil
+	}
+	return results
+}
+
+// Delete implement RemoteCacheClient.
+func (c *rueidisClient) Delete(ctx context.Context, key string) error {
+	return c.delete(ctx, key, func(ctx context.Context, key string) error {
+		return c.client.Do(ctx, c.client.B().Del().Key(key).Build()).Error()
+	})
+}
+
+// Stop implement RemoteCacheClient.
+func (c *rueidisClient) Stop() {
+	// Stop running async operations.
+	c.asyncQueue.stop()
+
+	c.client.Close()
+}
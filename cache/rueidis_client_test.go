@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRueidisClient_CacheTTL(t *testing.T) {
+	t.Run("defaults to one minute when unset", func(t *testing.T) {
+		c := &rueidisClient{config: RedisClientConfig{}}
+		require.Equal(t, time.Minute, c.cacheTTL())
+	})
+
+	t.Run("uses the configured TTL when positive", func(t *testing.T) {
+		c := &rueidisClient{config: RedisClientConfig{CacheTTL: 30 * time.Second}}
+		require.Equal(t, 30*time.Second, c.cacheTTL())
+	})
+}
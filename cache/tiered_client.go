@@ -0,0 +1,341 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var _ RemoteCacheClient = (*TieredClient)(nil)
+
+// TieredCacheConfig is the config accepted by TieredClient.
+type TieredCacheConfig struct {
+	// LocalMaxSizeBytes is the maximum amount of memory, in bytes, used by the L1 in-process cache.
+	LocalMaxSizeBytes int64 `yaml:"local_max_size_bytes" category:"advanced"`
+
+	// LocalTTL is the TTL applied to entries backfilled into the L1 cache after an L2 lookup.
+	LocalTTL time.Duration `yaml:"local_ttl" category:"advanced"`
+
+	// InvalidationChannel is the Redis pub/sub channel used to broadcast key invalidations to
+	// other replicas running a TieredClient against the same L2. Disabled if empty.
+	InvalidationChannel string `yaml:"invalidation_channel" category:"advanced"`
+}
+
+// RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet
+func (c *TieredCacheConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.Int64Var(&c.LocalMaxSizeBytes, prefix+".local-max-size-bytes", 128*1024*1024, "The maximum amount of memory, in bytes, used by the in-process (L1) cache.")
+	f.DurationVar(&c.LocalTTL, prefix+".local-ttl", 10*time.Second, "The TTL applied to entries held in the in-process (L1) cache.")
+	f.StringVar(&c.InvalidationChannel, prefix+".invalidation-channel", "", "Redis pub/sub channel used to broadcast key invalidations to other replicas of this cache. If empty, invalidations are not broadcast.")
+}
+
+// redisPubSub is implemented by RemoteCacheClient implementations that can also be used to publish
+// and subscribe to Redis pub/sub channels, such as redisClient. It's satisfied by embedding
+// redis.UniversalClient.
+type redisPubSub interface {
+	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+type tieredMetrics struct {
+	l1Hits         prometheus.Counter
+	l1Evictions    prometheus.Counter
+	lookupDuration prometheus.Histogram
+}
+
+func newTieredMetrics(reg prometheus.Registerer) *tieredMetrics {
+	return &tieredMetrics{
+		l1Hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "l1_hits_total",
+			Help: "Total number of lookups served from the L1 in-process cache without reaching L2.",
+		}),
+		l1Evictions: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "l1_evictions_total",
+			Help: "Total number of L1 in-process cache entries evicted, either due to size pressure, TTL expiry or a peer invalidation.",
+		}),
+		lookupDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "lookup_duration_seconds",
+			Help:    "End-to-end duration of a GetMulti lookup, across both L1 and L2.",
+			Buckets: prometheus.DefBuckets,
+		}),
+	}
+}
+
+// TieredClient is a RemoteCacheClient that composes a bounded in-process (L1) cache in front of
+// another RemoteCacheClient (L2), typically Redis or memcached. GetMulti is served from L1 first,
+// forwarding only misses to L2 and backfilling L1 with a shorter TTL; SetAsync and Delete write
+// through to both tiers.
+type TieredClient struct {
+	l1      *localCache
+	l2      RemoteCacheClient
+	config  TieredCacheConfig
+	metrics *tieredMetrics
+	logger  log.Logger
+
+	// instanceID tags every invalidation this instance publishes, so watchInvalidations can
+	// recognise and ignore its own messages instead of evicting an L1 entry it just populated.
+	instanceID string
+
+	cancelSubscription context.CancelFunc
+}
+
+// NewTieredClient makes a new TieredClient composing an L1 in-process cache in front of l2. If
+// config.InvalidationChannel is set and l2 supports Redis pub/sub, TieredClient subscribes to it so
+// that invalidations broadcast by peers evict the matching L1 entry.
+func NewTieredClient(logger log.Logger, name string, l2 RemoteCacheClient, config TieredCacheConfig, reg prometheus.Registerer) (*TieredClient, error) {
+	reg = prometheus.WrapRegistererWith(prometheus.Labels{"name": name}, reg)
+	logger = log.With(logger, "name", name)
+
+	metrics := newTieredMetrics(reg)
+	c := &TieredClient{
+		l1:         newLocalCache(config.LocalMaxSizeBytes, metrics),
+		l2:         l2,
+		config:     config,
+		metrics:    metrics,
+		logger:     logger,
+		instanceID: newInstanceID(),
+	}
+
+	if config.InvalidationChannel != "" {
+		if pubsub, ok := l2.(redisPubSub); ok {
+			ctx, cancel := context.WithCancel(context.Background())
+			c.cancelSubscription = cancel
+			go c.watchInvalidations(ctx, pubsub)
+		} else {
+			level.Warn(logger).Log("msg", "invalidation channel configured but L2 cache client does not support pub/sub, peer invalidations are disabled")
+		}
+	}
+
+	return c, nil
+}
+
+func (c *TieredClient) watchInvalidations(ctx context.Context, pubsub redisPubSub) {
+	sub := pubsub.Subscribe(ctx, c.config.InvalidationChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			if key, evict := c.shouldEvict(msg.Payload); evict {
+				if c.l1.delete(key) {
+					c.metrics.l1Evictions.Inc()
+				}
+			}
+		}
+	}
+}
+
+// shouldEvict decides, for a raw invalidation pub/sub payload, whether the local L1 entry it names
+// should be evicted. It returns false for a malformed payload or one published by this very
+// instance looping back through its own subscription, since the L1 entry it just wrote is still
+// current.
+func (c *TieredClient) shouldEvict(payload string) (key string, evict bool) {
+	origin, key, ok := splitInvalidationPayload(payload)
+	if !ok || origin == c.instanceID {
+		return "", false
+	}
+	return key, true
+}
+
+// publishInvalidation broadcasts key to peers over config.InvalidationChannel, best-effort. The
+// message is tagged with c.instanceID so watchInvalidations can tell its own publishes apart from
+// a peer's and avoid evicting the L1 entry it just wrote.
+func (c *TieredClient) publishInvalidation(ctx context.Context, key string) {
+	if c.config.InvalidationChannel == "" {
+		return
+	}
+	pubsub, ok := c.l2.(redisPubSub)
+	if !ok {
+		return
+	}
+	if err := pubsub.Publish(ctx, c.config.InvalidationChannel, c.instanceID+":"+key).Err(); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to publish cache invalidation", "key", key, "err", err)
+	}
+}
+
+// splitInvalidationPayload parses a "<instanceID>:<key>" invalidation message.
+func splitInvalidationPayload(payload string) (origin, key string, ok bool) {
+	origin, key, ok = strings.Cut(payload, ":")
+	return origin, key, ok
+}
+
+// newInstanceID returns a random identifier used to tag invalidations published by this
+// TieredClient, so peers sharing the same process-local cache library can still be distinguished
+// from this instance's own pub/sub loopback.
+func newInstanceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand.Read on the OS CSPRNG practically never fails; if it somehow does, this
+		// instance falls back to a fixed ID, and then also ignores invalidations from any peer
+		// that fell back to the same ID.
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetAsync implement RemoteCacheClient.
+func (c *TieredClient) SetAsync(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.l1.set(key, value, c.localTTL(ttl))
+	c.publishInvalidation(ctx, key)
+	return c.l2.SetAsync(ctx, key, value, ttl)
+}
+
+// GetMulti implement RemoteCacheClient.
+func (c *TieredClient) GetMulti(ctx context.Context, keys []string, opts ...Option) map[string][]byte {
+	if len(keys) == 0 {
+		return nil
+	}
+	start := time.Now()
+	defer func() { c.metrics.lookupDuration.Observe(time.Since(start).Seconds()) }()
+
+	results := make(map[string][]byte, len(keys))
+	var misses []string
+	for _, key := range keys {
+		if value, ok := c.l1.get(key); ok {
+			c.metrics.l1Hits.Inc()
+			results[key] = value
+			continue
+		}
+		misses = append(misses, key)
+	}
+	if len(misses) == 0 {
+		return results
+	}
+
+	l2Results := c.l2.GetMulti(ctx, misses, opts...)
+	for key, value := range l2Results {
+		results[key] = value
+		c.l1.set(key, value, c.config.LocalTTL)
+	}
+	return results
+}
+
+// localTTL returns the TTL to use for an L1 entry written directly via SetAsync, capped to
+// config.LocalTTL so a long L2 TTL can't keep a stale local copy around after an invalidation is
+// missed.
+func (c *TieredClient) localTTL(ttl time.Duration) time.Duration {
+	if c.config.LocalTTL > 0 && (ttl <= 0 || ttl > c.config.LocalTTL) {
+		return c.config.LocalTTL
+	}
+	return ttl
+}
+
+// Delete implement RemoteCacheClient.
+func (c *TieredClient) Delete(ctx context.Context, key string) error {
+	c.l1.delete(key)
+	c.publishInvalidation(ctx, key)
+	return c.l2.Delete(ctx, key)
+}
+
+// Stop implement RemoteCacheClient.
+func (c *TieredClient) Stop() {
+	if c.cancelSubscription != nil {
+		c.cancelSubscription()
+	}
+	c.l2.Stop()
+}
+
+// localCacheEntry is a single L1 cache entry.
+type localCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// localCache is a bounded, size- and TTL-limited in-process LRU used as the L1 tier of
+// TieredClient. It's intentionally simple (a mutex-guarded map and list) rather than a
+// general-purpose cache, since TieredClient is its only caller.
+type localCache struct {
+	mu          sync.Mutex
+	maxSize     int64
+	currentSize int64
+	ll          *list.List
+	items       map[string]*list.Element
+
+	metrics *tieredMetrics
+}
+
+func newLocalCache(maxSize int64, metrics *tieredMetrics) *localCache {
+	return &localCache{
+		maxSize: maxSize,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+		metrics: metrics,
+	}
+}
+
+func (l *localCache) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*localCacheEntry)
+	if time.Now().After(entry.expires) {
+		l.removeElement(elem)
+		l.metrics.l1Evictions.Inc()
+		return nil, false
+	}
+	l.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (l *localCache) set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.items[key]; ok {
+		l.removeElement(elem)
+	}
+
+	entry := &localCacheEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	l.items[key] = l.ll.PushFront(entry)
+	l.currentSize += int64(len(value))
+
+	for l.currentSize > l.maxSize && l.ll.Len() > 0 {
+		oldest := l.ll.Back()
+		l.removeElement(oldest)
+		l.metrics.l1Evictions.Inc()
+	}
+}
+
+// delete removes key from the cache, reporting whether it was present.
+func (l *localCache) delete(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.items[key]
+	if ok {
+		l.removeElement(elem)
+	}
+	return ok
+}
+
+// removeElement removes elem from the list and map; callers must hold l.mu.
+func (l *localCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*localCacheEntry)
+	l.ll.Remove(elem)
+	delete(l.items, entry.key)
+	l.currentSize -= int64(len(entry.value))
+}
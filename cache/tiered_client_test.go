@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTieredClient_IgnoresSelfOriginatedInvalidations(t *testing.T) {
+	c := &TieredClient{
+		l1:         newLocalCache(1024*1024, newTieredMetrics(prometheus.NewRegistry())),
+		logger:     log.NewNopLogger(),
+		config:     TieredCacheConfig{InvalidationChannel: "invalidations"},
+		instanceID: "self",
+	}
+
+	c.l1.set("my-key", []byte("value"), time.Minute)
+
+	// A message this instance published itself must not evict the entry it just wrote. This is
+	// the same decision watchInvalidations makes for every message it receives off the channel.
+	key, evict := c.shouldEvict("self:my-key")
+	require.False(t, evict)
+	if evict {
+		c.l1.delete(key)
+	}
+	_, found := c.l1.get("my-key")
+	require.True(t, found)
+
+	// A peer's invalidation for the same key must still evict it.
+	key, evict = c.shouldEvict("peer:my-key")
+	require.True(t, evict)
+	require.Equal(t, "my-key", key)
+	c.l1.delete(key)
+	_, found = c.l1.get("my-key")
+	require.False(t, found)
+}
+
+func TestTieredClient_ShouldEvict(t *testing.T) {
+	c := &TieredClient{instanceID: "self"}
+
+	t.Run("peer-originated invalidation is evicted", func(t *testing.T) {
+		key, evict := c.shouldEvict("peer:my-key")
+		require.True(t, evict)
+		require.Equal(t, "my-key", key)
+	})
+
+	t.Run("self-originated invalidation is ignored", func(t *testing.T) {
+		_, evict := c.shouldEvict("self:my-key")
+		require.False(t, evict)
+	})
+
+	t.Run("malformed payload is ignored", func(t *testing.T) {
+		_, evict := c.shouldEvict("no-separator")
+		require.False(t, evict)
+	})
+}
+
+func TestSplitInvalidationPayload(t *testing.T) {
+	origin, key, ok := splitInvalidationPayload("abc123:some:key:with:colons")
+	require.True(t, ok)
+	require.Equal(t, "abc123", origin)
+	require.Equal(t, "some:key:with:colons", key)
+
+	_, _, ok = splitInvalidationPayload("no-separator")
+	require.False(t, ok)
+}
+
+func TestNewInstanceID_Unique(t *testing.T) {
+	require.NotEqual(t, newInstanceID(), newInstanceID())
+}